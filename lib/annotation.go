@@ -0,0 +1,93 @@
+package rat
+
+// VisualRow maps one wrapped screen row back to its logical line and the
+// rune offset within that line where the row starts, as produced by
+// Buffer.WrappedLines.
+type VisualRow struct {
+	Line       int
+	RuneOffset int
+	Runes      []StyledRune
+}
+
+// RawLine is a single line of raw command output, as consumed by Annotators
+// from Buffer.RawLines.
+type RawLine struct {
+	Index int
+	Text  string
+}
+
+// Annotation is attached to a buffer line by an Annotator and surfaced to
+// event handlers via AnnotationsForLine.
+type Annotation interface {
+	Class() string
+	Val() string
+}
+
+// Annotator runs against a Buffer via Buffer.AnnotateWith, typically on its
+// own goroutine (cmdPager.RunCommand starts one per annotator), producing
+// Annotations for lines as they arrive.
+type Annotator interface {
+	Annotate(b Buffer)
+}
+
+// StyledAnnotation is an Annotation that additionally carries per-rune
+// styling, merged into the raw buffer rendering by drawContent.
+type StyledAnnotation interface {
+	Annotation
+	Runes() []StyledRune
+}
+
+type styledAnnotation struct {
+	class string
+	val   string
+	runes []StyledRune
+}
+
+func newStyledAnnotation(class, val string, runes []StyledRune) StyledAnnotation {
+	return &styledAnnotation{class: class, val: val, runes: runes}
+}
+
+func (a *styledAnnotation) Class() string {
+	return a.class
+}
+
+func (a *styledAnnotation) Val() string {
+	return a.val
+}
+
+func (a *styledAnnotation) Runes() []StyledRune {
+	return a.runes
+}
+
+// mergeStyledRunes overlays styled runes onto base at matching positions,
+// leaving base untouched wherever overlay has no entry.
+func mergeStyledRunes(base []StyledRune, overlay []StyledRune) []StyledRune {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	merged := make([]StyledRune, len(base))
+	copy(merged, base)
+
+	for i := 0; i < len(overlay) && i < len(merged); i++ {
+		merged[i] = overlay[i]
+	}
+
+	return merged
+}
+
+// mergeStyledRunesAt is mergeStyledRunes for a wrapped visual row: overlay is
+// indexed by rune position in the full logical line, so it's sliced to the
+// [offset, offset+len(base)) window that this row covers before merging.
+func mergeStyledRunesAt(base []StyledRune, overlay []StyledRune, offset int) []StyledRune {
+	if offset >= len(overlay) {
+		return base
+	}
+
+	end := offset + len(base)
+	if end > len(overlay) {
+		end = len(overlay)
+	}
+
+	return mergeStyledRunes(base, overlay[offset:end])
+}
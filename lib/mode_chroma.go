@@ -0,0 +1,205 @@
+package rat
+
+import (
+	"math"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// chromaMode highlights buffer content by running it through a chroma lexer.
+// Mode names look like "chroma:go", "chroma:json" or "chroma:auto", optionally
+// suffixed with a style name, e.g. "chroma:go:monokai".
+type chromaMode struct {
+	lexerName string
+	styleName string
+}
+
+func resolveDynamicMode(modeName string) (Mode, bool) {
+	if strings.HasPrefix(modeName, "chroma:") {
+		return newChromaMode(modeName), true
+	}
+
+	return nil, false
+}
+
+func newChromaMode(spec string) *chromaMode {
+	parts := strings.SplitN(spec, ":", 3)
+
+	m := &chromaMode{lexerName: "auto", styleName: "monokai"}
+
+	if len(parts) > 1 && parts[1] != "" {
+		m.lexerName = parts[1]
+	}
+
+	if len(parts) > 2 && parts[2] != "" {
+		m.styleName = parts[2]
+	}
+
+	return m
+}
+
+func (m *chromaMode) AddEventListeners(ctx Context) func(Pager) {
+	return func(p Pager) {}
+}
+
+func (m *chromaMode) InitAnnotators(ctx Context) func() []Annotator {
+	return func() []Annotator {
+		return []Annotator{newChromaAnnotator(m.lexerName, m.styleName, ctx)}
+	}
+}
+
+type chromaAnnotator struct {
+	lexerName string
+	styleName string
+	cmd       string
+}
+
+func newChromaAnnotator(lexerName, styleName string, ctx Context) *chromaAnnotator {
+	return &chromaAnnotator{lexerName: lexerName, styleName: styleName, cmd: InterpolateContext("{0}", ctx)}
+}
+
+func (a *chromaAnnotator) resolveLexer(sample string) chroma.Lexer {
+	if a.lexerName != "auto" {
+		if l := lexers.Get(a.lexerName); l != nil {
+			return l
+		}
+	}
+
+	if l := lexers.Analyse(sample); l != nil {
+		return l
+	}
+
+	if l := lexers.Match(a.cmd); l != nil {
+		return l
+	}
+
+	return lexers.Fallback
+}
+
+// Annotate lexes the whole buffer seen so far on every new line rather than
+// tokenising each line in isolation, since chroma's regex lexers aren't
+// resumable from a per-line byte offset: this is what lets multi-line tokens
+// (block comments, triple-quoted strings) survive a chunk/line boundary.
+// The 1KB sampling window used for lexer auto-detection only applies to
+// "chroma:auto" — an explicitly named lexer (e.g. "chroma:go") starts
+// annotating from the first line.
+func (a *chromaAnnotator) Annotate(b Buffer) {
+	style := styles.Get(a.styleName)
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	var lexer chroma.Lexer
+	var sample strings.Builder
+	var text strings.Builder
+	numLines := 0
+
+	for raw := range b.RawLines() {
+		text.WriteString(raw.Text)
+		text.WriteByte('\n')
+		numLines++
+
+		if lexer == nil {
+			if a.lexerName != "auto" {
+				lexer = chroma.Coalesce(a.resolveLexer(""))
+			} else {
+				sample.WriteString(raw.Text)
+				sample.WriteByte('\n')
+
+				if sample.Len() < 1024 {
+					continue
+				}
+
+				lexer = chroma.Coalesce(a.resolveLexer(sample.String()))
+			}
+		}
+
+		a.relex(b, lexer, style, text.String(), numLines)
+	}
+}
+
+// relex tokenises the full accumulated text and re-annotates every line seen
+// so far, overwriting each line's previous "chroma" annotation.
+func (a *chromaAnnotator) relex(b Buffer, lexer chroma.Lexer, style *chroma.Style, text string, numLines int) {
+	it, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return
+	}
+
+	lineRunes := make([][]StyledRune, numLines)
+	line := 0
+
+	for token := it(); token != chroma.EOFType.Token(); token = it() {
+		entry := style.Get(token.Type)
+		fg, attr := termboxAttrFromChromaEntry(entry)
+		st := gTermStyles.Get(attr, fg)
+
+		for _, r := range token.Value {
+			if r == '\n' {
+				line++
+				continue
+			}
+
+			if line < len(lineRunes) {
+				lineRunes[line] = append(lineRunes[line], NewStyledRune(r, st))
+			}
+		}
+	}
+
+	for i, runes := range lineRunes {
+		b.PutAnnotation(i, newStyledAnnotation("chroma", a.lexerName, runes))
+	}
+}
+
+func termboxAttrFromChromaEntry(entry chroma.StyleEntry) (termbox.Attribute, termbox.Attribute) {
+	var attr termbox.Attribute
+
+	if entry.Bold == chroma.Yes {
+		attr |= termbox.AttrBold
+	}
+
+	if entry.Underline == chroma.Yes {
+		attr |= termbox.AttrUnderline
+	}
+
+	fg := termbox.ColorDefault
+	if entry.Colour.IsSet() {
+		idx := xterm256FromRGB(entry.Colour.Red(), entry.Colour.Green(), entry.Colour.Blue())
+		fg = termbox.Attribute(idx) + termbox.ColorBlack + 1
+	}
+
+	return fg, attr
+}
+
+// xterm256FromRGB quantizes an RGB color into the xterm 256-color palette,
+// preferring the 24-step greyscale ramp for neutral colors (it resolves grey
+// far more precisely than the 6×6×6 cube) and the cube otherwise, so distinct
+// hues with similar brightness (e.g. a string vs. a keyword) don't collapse
+// into the same shade the way a brightness-only mapping would.
+func xterm256FromRGB(r, g, b uint8) int {
+	if r == g && g == b {
+		return greyscaleXterm256(r)
+	}
+
+	cube := func(v uint8) int {
+		return int(math.Round(float64(v) / 255 * 5))
+	}
+
+	return 16 + 36*cube(r) + 6*cube(g) + cube(b)
+}
+
+func greyscaleXterm256(v uint8) int {
+	if v < 8 {
+		return 16
+	}
+
+	if v > 248 {
+		return 231
+	}
+
+	return 232 + int(math.Round((float64(v)-8)/247*24))
+}
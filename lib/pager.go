@@ -1,6 +1,7 @@
 package rat
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -20,6 +21,7 @@ type Pager interface {
 	ScrollDown()
 	PageUp()
 	PageDown()
+	Wrap() bool
 }
 
 type cmdPager struct {
@@ -27,12 +29,19 @@ type cmdPager struct {
 	cmd                      string
 	ctx                      Context
 	command                  ShellCommand
+	cmdCancel                context.CancelFunc
+	lastErr                  error
 	buffer                   Buffer
 	scrollOffsetY            int
 	cursorY                  int
 	stop                     chan bool
 	eventListeners           map[keyEvent]func()
 	annotationEventListeners map[keyEvent]map[string]func(Context)
+	search                   searchState
+	onCursorMove             func(cursorY int)
+	wrap                     bool
+	visualRows               []VisualRow
+	visualRowsLines          int
 
 	box        Box
 	headerBox  Box
@@ -40,20 +49,29 @@ type cmdPager struct {
 }
 
 func NewCmdPager(modeNames string, cmd string, ctx Context) Pager {
+	ensureThemeInitialized()
+
 	p := &cmdPager{}
 	p.cmd = cmd
 	p.ctx = ctx
+	p.wrap = true
 
 	p.eventListeners = make(map[keyEvent]func())
 	p.annotationEventListeners = make(map[keyEvent]map[string]func(Context))
 
 	p.addDefaultListeners()
+	p.addSearchListeners()
 
 	splitModeNames := strings.Split(modeNames, ",")
 	p.modes = make([]Mode, 0, len(splitModeNames))
 
 	for _, modeName := range splitModeNames {
-		if mode, ok := modes[modeName]; ok {
+		mode, ok := modes[modeName]
+		if !ok {
+			mode, ok = resolveDynamicMode(modeName)
+		}
+
+		if ok {
 			p.modes = append(p.modes, mode)
 
 			mode.AddEventListeners(ctx)(p)
@@ -86,8 +104,22 @@ func (p *cmdPager) Destroy() {
 }
 
 func (p *cmdPager) Stop() {
-	p.command.Close()
-	p.buffer.Close()
+	// Close() runs the graceful SIGTERM-then-grace-period-then-SIGKILL sequence
+	// in terminate(). cmdCancel must only be called afterwards, as cleanup for
+	// the context's resources — canceling it first would cascade into
+	// exec.CommandContext's default Cancel behavior, which SIGKILLs the
+	// process immediately and defeats the graceful shutdown entirely.
+	if p.command != nil {
+		p.command.Close()
+	}
+
+	if p.cmdCancel != nil {
+		p.cmdCancel()
+	}
+
+	if p.buffer != nil {
+		p.buffer.Close()
+	}
 }
 
 func (p *cmdPager) Reload() {
@@ -95,27 +127,43 @@ func (p *cmdPager) Reload() {
 	p.RunCommand()
 }
 
-func (p *cmdPager) RunCommand() {
-	var err error
-
-	if p.command, err = NewShellCommand(p.InterpolatedCmd()); err != nil {
-		panic(err)
+// RunCommand starts the pager's command and returns any error instead of
+// panicking. The error is also kept in lastErr, which drawHeader shows in
+// the header.
+func (p *cmdPager) RunCommand() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cmdCancel = cancel
+
+	command, err := NewShellCommand(ctx, p.InterpolatedCmd())
+	if err != nil {
+		p.lastErr = err
+		return err
 	}
 
+	p.command = command
 	p.buffer = NewBuffer(p.command)
+	p.lastErr = nil
 
 	for _, m := range p.modes {
 		for _, a := range m.InitAnnotators(p.ctx)() {
 			go p.buffer.AnnotateWith(a)
 		}
 	}
+
+	p.rebuildVisualRows()
+
+	return nil
 }
 
 func (p *cmdPager) HandleEvent(ke keyEvent) bool {
 	p.buffer.Lock()
 	defer p.buffer.Unlock()
 
-	annotations := p.buffer.AnnotationsForLine(p.cursorY)
+	if p.searchActive() {
+		return p.handleSearchKey(ke)
+	}
+
+	annotations := p.buffer.AnnotationsForLine(p.logicalLine(p.cursorY))
 
 	ctx := Context{}
 	for _, a := range annotations {
@@ -151,24 +199,63 @@ func (p *cmdPager) GetBox() Box {
 func (p *cmdPager) layout() {
 	p.headerBox = NewBox(p.box.Left(), p.box.Top(), p.box.Width(), 1)
 	p.contentBox = NewBox(p.box.Left(), p.box.Top()+1, p.box.Width(), p.box.Height()-1)
+	p.rebuildVisualRows()
 }
 
 func (p *cmdPager) drawHeader() {
 	p.headerBox.DrawStyledRunes(1, 0, StyledRunesFromString(p.InterpolatedCmd(), gTermStyles.Get(termbox.AttrUnderline, termbox.ColorDefault)))
 
-	pagerInfo := StyledRunesFromString(fmt.Sprintf(" %d %d/%d ", p.buffer.NumAnnotations(), p.cursorY+1, p.buffer.NumLines()), gTermStyles.Get(termbox.AttrBold, termbox.ColorDefault))
+	if p.lastErr != nil {
+		errInfo := StyledRunesFromString(fmt.Sprintf(" error: %s ", p.lastErr), ThemedStyle(RoleHeader))
+		p.headerBox.DrawStyledRunes(p.headerBox.Width()-len(errInfo), 0, errInfo)
+		return
+	}
+
+	pagerInfo := StyledRunesFromString(fmt.Sprintf(" %d %d/%d%s", p.buffer.NumAnnotations(), p.logicalLine(p.cursorY)+1, p.buffer.NumLines(), p.searchHeaderInfo()), ThemedStyle(RoleHeader))
 	p.headerBox.DrawStyledRunes(p.headerBox.Width()-len(pagerInfo), 0, pagerInfo)
 }
 
 func (p *cmdPager) drawContent() {
-	p.contentBox.DrawStyledRune(1, p.cursorY-p.scrollOffsetY, NewStyledRune('>', gTermStyles.Get(termbox.ColorRed, termbox.ColorDefault)))
+	p.ensureVisualRowsFresh()
+
+	p.contentBox.DrawStyledRune(1, p.cursorY-p.scrollOffsetY, NewStyledRune('>', ThemedStyle(RoleCursor)))
 
-	for y, line := range p.buffer.StyledLines(p.scrollOffsetY, p.contentBox.Height()) {
-		p.contentBox.DrawStyledRunes(3, y, []StyledRune(line))
+	if !p.wrap {
+		for y, line := range p.buffer.StyledLines(p.scrollOffsetY, p.contentBox.Height()) {
+			rendered := []StyledRune(line)
+
+			for _, a := range p.buffer.AnnotationsForLine(p.scrollOffsetY + y) {
+				if sa, ok := a.(StyledAnnotation); ok {
+					rendered = mergeStyledRunes(rendered, sa.Runes())
+				}
+			}
+
+			p.contentBox.DrawStyledRunes(3, y, rendered)
+		}
+
+		return
+	}
+
+	for y := 0; y < p.contentBox.Height() && p.scrollOffsetY+y < len(p.visualRows); y++ {
+		row := p.visualRows[p.scrollOffsetY+y]
+		rendered := row.Runes
+
+		for _, a := range p.buffer.AnnotationsForLine(row.Line) {
+			if sa, ok := a.(StyledAnnotation); ok {
+				rendered = mergeStyledRunesAt(rendered, sa.Runes(), row.RuneOffset)
+			}
+		}
+
+		p.contentBox.DrawStyledRunes(3, y, rendered)
 	}
 }
 
 func (p *cmdPager) Render() {
+	if p.buffer == nil {
+		p.drawHeader()
+		return
+	}
+
 	p.buffer.Lock()
 	p.drawHeader()
 	p.drawContent()
@@ -178,8 +265,8 @@ func (p *cmdPager) Render() {
 func (p *cmdPager) MoveCursorToY(cursorY int) {
 	if cursorY < 0 {
 		p.cursorY = 0
-	} else if cursorY >= p.buffer.NumLines() {
-		p.cursorY = p.buffer.NumLines() - 1
+	} else if cursorY >= p.numRows() {
+		p.cursorY = p.numRows() - 1
 	} else {
 		p.cursorY = cursorY
 	}
@@ -189,6 +276,10 @@ func (p *cmdPager) MoveCursorToY(cursorY int) {
 	} else if p.cursorY > p.scrollOffsetY+p.contentBox.Height()-1 {
 		p.ScrollToY(p.cursorY - (p.contentBox.Height() - 1))
 	}
+
+	if p.onCursorMove != nil {
+		p.onCursorMove(p.cursorY)
+	}
 }
 
 func (p *cmdPager) MoveCursorY(delta int) {
@@ -198,9 +289,9 @@ func (p *cmdPager) MoveCursorY(delta int) {
 func (p *cmdPager) ScrollToY(scrollY int) {
 	if scrollY < 0 {
 		p.scrollOffsetY = 0
-	} else if scrollY >= p.buffer.NumLines()-p.contentBox.Height() {
-		if p.buffer.NumLines() > p.contentBox.Height() {
-			p.scrollOffsetY = p.buffer.NumLines() - p.contentBox.Height()
+	} else if scrollY >= p.numRows()-p.contentBox.Height() {
+		if p.numRows() > p.contentBox.Height() {
+			p.scrollOffsetY = p.numRows() - p.contentBox.Height()
 		} else {
 			p.scrollOffsetY = 0
 		}
@@ -235,6 +326,7 @@ func (p *cmdPager) addDefaultListeners() {
 	p.AddEventListener("pgup", p.PageUp)
 	p.AddEventListener("g", p.CursorFirstLine)
 	p.AddEventListener("S-g", p.CursorLastLine)
+	p.AddEventListener("w", p.ToggleWrap)
 }
 
 func (p *cmdPager) CursorUp() {
@@ -250,7 +342,7 @@ func (p *cmdPager) CursorFirstLine() {
 }
 
 func (p *cmdPager) CursorLastLine() {
-	p.MoveCursorToY(p.buffer.NumLines())
+	p.MoveCursorToY(p.numRows())
 }
 
 func (p *cmdPager) ScrollUp() {
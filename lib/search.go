@@ -0,0 +1,337 @@
+package rat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type searchMatch struct {
+	line      int
+	positions []int
+	score     int
+}
+
+// searchState's fields are shared between the UI goroutine (key handling,
+// Render) and the matcher goroutine spawned by runSearch, so every access
+// goes through mu.
+type searchState struct {
+	active   bool
+	query    []rune
+	matches  []searchMatch
+	matchIdx int
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+}
+
+func (p *cmdPager) addSearchListeners() {
+	p.AddEventListener("/", p.startSearch)
+	p.AddEventListener("n", p.nextMatch)
+	p.AddEventListener("S-n", p.prevMatch)
+	p.AddEventListener("esc", p.cancelSearch)
+}
+
+func (p *cmdPager) searchActive() bool {
+	p.search.mu.Lock()
+	defer p.search.mu.Unlock()
+
+	return p.search.active
+}
+
+func (p *cmdPager) startSearch() {
+	p.search.mu.Lock()
+	defer p.search.mu.Unlock()
+
+	p.search.active = true
+	p.search.query = p.search.query[:0]
+	p.search.matches = nil
+	p.search.matchIdx = 0
+}
+
+func (p *cmdPager) cancelSearch() {
+	p.search.mu.Lock()
+	defer p.search.mu.Unlock()
+
+	p.search.active = false
+	p.search.query = nil
+	p.clearMatchAnnotationsLocked()
+
+	if p.search.cancel != nil {
+		p.search.cancel()
+	}
+}
+
+func (p *cmdPager) nextMatch() {
+	p.search.mu.Lock()
+
+	if len(p.search.matches) == 0 {
+		p.search.mu.Unlock()
+		return
+	}
+
+	p.search.matchIdx = (p.search.matchIdx + 1) % len(p.search.matches)
+	line := p.search.matches[p.search.matchIdx].line
+	p.search.mu.Unlock()
+
+	p.MoveCursorToY(p.visualRowForLine(line))
+}
+
+func (p *cmdPager) prevMatch() {
+	p.search.mu.Lock()
+
+	if len(p.search.matches) == 0 {
+		p.search.mu.Unlock()
+		return
+	}
+
+	p.search.matchIdx = (p.search.matchIdx - 1 + len(p.search.matches)) % len(p.search.matches)
+	line := p.search.matches[p.search.matchIdx].line
+	p.search.mu.Unlock()
+
+	p.MoveCursorToY(p.visualRowForLine(line))
+}
+
+// handleSearchKey consumes a key event while the search prompt is active,
+// bypassing the normal eventListeners dispatch so arbitrary runes can be
+// typed into the query.
+func (p *cmdPager) handleSearchKey(ke keyEvent) bool {
+	switch {
+	case ke == KeyEventFromString("esc"):
+		p.cancelSearch()
+	case ke == KeyEventFromString("enter"):
+		p.search.mu.Lock()
+		p.search.active = false
+		p.search.mu.Unlock()
+	case ke == KeyEventFromString("backspace"):
+		p.search.mu.Lock()
+		hadQuery := len(p.search.query) > 0
+		if hadQuery {
+			p.search.query = p.search.query[:len(p.search.query)-1]
+		}
+		p.search.mu.Unlock()
+
+		if hadQuery {
+			p.runSearch()
+		}
+	default:
+		if r, ok := ke.Rune(); ok {
+			p.search.mu.Lock()
+			p.search.query = append(p.search.query, r)
+			p.search.mu.Unlock()
+
+			p.runSearch()
+		}
+	}
+
+	return true
+}
+
+// runSearch cancels any in-flight matcher and starts a fresh one on a worker
+// goroutine, so a burst of keystrokes only ever has one search running.
+func (p *cmdPager) runSearch() {
+	p.search.mu.Lock()
+
+	if p.search.cancel != nil {
+		p.search.cancel()
+	}
+
+	query := string(p.search.query)
+
+	if len(query) == 0 {
+		p.clearMatchAnnotationsLocked()
+		p.search.matches = nil
+		p.search.mu.Unlock()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.search.cancel = cancel
+	p.search.mu.Unlock()
+
+	buffer := p.buffer
+
+	go func() {
+		matches := fuzzyMatchLines(ctx, buffer, query)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// p.buffer.Lock() guards cursorY/scrollOffsetY/visualRows too (see
+		// Render, HandleEvent), so it must be held before MoveCursorToY is
+		// called from this goroutine, same as every other caller of it.
+		p.buffer.Lock()
+		defer p.buffer.Unlock()
+
+		p.search.mu.Lock()
+		p.search.matches = matches
+		p.search.matchIdx = 0
+		p.applyMatchAnnotationsLocked()
+		moveToLine := -1
+		if len(matches) > 0 {
+			moveToLine = matches[0].line
+		}
+		p.search.mu.Unlock()
+
+		if moveToLine >= 0 {
+			p.MoveCursorToY(p.visualRowForLine(moveToLine))
+		}
+	}()
+}
+
+// clearMatchAnnotationsLocked and applyMatchAnnotationsLocked assume
+// p.search.mu is already held by the caller.
+func (p *cmdPager) clearMatchAnnotationsLocked() {
+	for _, m := range p.search.matches {
+		p.buffer.PutAnnotation(m.line, nil)
+	}
+}
+
+func (p *cmdPager) applyMatchAnnotationsLocked() {
+	for _, m := range p.search.matches {
+		lines := p.buffer.StyledLines(m.line, 1)
+		if len(lines) == 0 {
+			continue
+		}
+
+		// Seed every index with the line's own styled rune, not the zero
+		// value, since mergeStyledRunes copies this overlay over the base
+		// rune-for-rune wherever it has an entry — a zero-value hole would
+		// blank out the rest of the line instead of leaving it untouched.
+		runes := append([]StyledRune(nil), []StyledRune(lines[0])...)
+		for _, pos := range m.positions {
+			if pos < len(runes) {
+				runes[pos] = NewStyledRune(runes[pos].Rune(), ThemedStyle(RoleMatch))
+			}
+		}
+
+		p.buffer.PutAnnotation(m.line, newStyledAnnotation("search-match", string(p.search.query), runes))
+	}
+}
+
+// searchHeaderInfo renders the search status shown next to the cursor
+// position: the typed query while the prompt is active, otherwise the
+// "idx/total" counter once there are results.
+func (p *cmdPager) searchHeaderInfo() string {
+	p.search.mu.Lock()
+	defer p.search.mu.Unlock()
+
+	if !p.search.active && len(p.search.matches) == 0 {
+		return ""
+	}
+
+	if p.search.active {
+		return fmt.Sprintf(" /%s", string(p.search.query))
+	}
+
+	return fmt.Sprintf(" %d/%d ", p.search.matchIdx+1, len(p.search.matches))
+}
+
+// fuzzyMatchLines scores every line against query using an fzf v1 style
+// algorithm: smart-case subsequence matching with bonuses for word boundaries,
+// consecutive runs and camelCase transitions. It returns as soon as ctx is
+// cancelled so a newer keystroke can take over.
+func fuzzyMatchLines(ctx context.Context, b Buffer, query string) []searchMatch {
+	smartCase := query == strings.ToLower(query)
+
+	var matches []searchMatch
+
+	for i := 0; i < b.NumLines(); i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		lines := b.StyledLines(i, 1)
+		if len(lines) == 0 {
+			continue
+		}
+
+		text := styledLineText(lines[0])
+
+		positions, score, ok := fuzzyMatchV1(text, query, smartCase)
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, searchMatch{line: i, positions: positions, score: score})
+	}
+
+	return matches
+}
+
+// fuzzyMatchV1 finds the first subsequence match of needle in haystack and
+// scores it, with bonuses for matches at word boundaries, consecutive runs and
+// camelCase transitions, same bonus shape as fzf's v1 algorithm. With
+// foldCase, haystack runes are lowercased for the comparison only, so the
+// bonuses are still judged on haystack's original case.
+func fuzzyMatchV1(haystack, needle string, foldCase bool) ([]int, int, bool) {
+	if needle == "" {
+		return nil, 0, false
+	}
+
+	h := []rune(haystack)
+	n := []rune(needle)
+
+	positions := make([]int, 0, len(n))
+	score := 0
+	ni := 0
+	prevMatched := false
+
+	for hi := 0; hi < len(h) && ni < len(n); hi++ {
+		hr := h[hi]
+		if foldCase {
+			hr = unicode.ToLower(hr)
+		}
+
+		if hr != n[ni] {
+			prevMatched = false
+			continue
+		}
+
+		positions = append(positions, hi)
+		score += 16
+
+		if hi == 0 || isWordBoundary(h[hi-1]) {
+			score += 8
+		}
+
+		if prevMatched {
+			score += 4
+		}
+
+		if hi > 0 && isCamelBoundary(h[hi-1], h[hi]) {
+			score += 6
+		}
+
+		prevMatched = true
+		ni++
+	}
+
+	if ni != len(n) {
+		return nil, 0, false
+	}
+
+	return positions, score, true
+}
+
+func isWordBoundary(r rune) bool {
+	return r == ' ' || r == '_' || r == '-' || r == '/' || r == '.'
+}
+
+func isCamelBoundary(prev, cur rune) bool {
+	return prev >= 'a' && prev <= 'z' && cur >= 'A' && cur <= 'Z'
+}
+
+func styledLineText(line StyledLine) string {
+	runes := []StyledRune(line)
+	out := make([]rune, len(runes))
+
+	for i, sr := range runes {
+		out[i] = sr.Rune()
+	}
+
+	return string(out)
+}
@@ -1,27 +1,36 @@
 package rat
 
 import (
+	"context"
 	"io"
 	"os"
 	"os/exec"
-	"syscall"
-    "strconv"
+	"time"
 )
 
+const terminateGracePeriod = 2 * time.Second
+
 type ShellCommand interface {
 	io.ReadCloser
 }
 
 type shellCommand struct {
-	cmd *exec.Cmd
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
 	io.Reader
 }
 
-func NewShellCommand(c string) (ShellCommand, error) {
-	sc := &shellCommand{}
+// NewShellCommand starts c under ctx. Close terminates the whole process
+// group/tree rather than just the shell. Cancelling ctx only kills the shell
+// process itself (exec.CommandContext's default), without the group
+// termination or grace period that Close gives.
+func NewShellCommand(ctx context.Context, c string) (ShellCommand, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sc := &shellCommand{cancel: cancel}
+	sc.cmd = exec.CommandContext(ctx, os.Getenv("SHELL"), "-c", c)
+	setProcAttr(sc.cmd)
 
-	sc.cmd = exec.Command(os.Getenv("SHELL"), "-c", c)
-	sc.cmd.SysProcAttr = &syscall.SysProcAttr{}
 	var (
 		stdout io.Reader
 		stderr io.Reader
@@ -29,27 +38,31 @@ func NewShellCommand(c string) (ShellCommand, error) {
 	)
 
 	if stdout, err = sc.cmd.StdoutPipe(); err != nil {
+		cancel()
 		return sc, err
 	}
 
 	if stderr, err = sc.cmd.StderrPipe(); err != nil {
+		cancel()
 		return sc, err
 	}
 
 	sc.Reader = io.MultiReader(stdout, stderr)
 
-	err = sc.cmd.Start()
+	if err = sc.cmd.Start(); err != nil {
+		cancel()
+		return sc, err
+	}
 
-	return sc, err
+	return sc, nil
 }
 
 func (sc *shellCommand) Close() error {
-	//err := syscall.Kill(-sc.cmd.Process.Pid, syscall.SIGTERM)
-    kill := exec.Command("TASKKILL", "/T", "/F", "/PID", strconv.Itoa(sc.cmd.Process.Pid))
-    kill.Stderr = os.Stderr
-    kill.Stdout = os.Stdout
-    kill.Run()
-    //return kill.Run()
-	//sc.cmd.Wait()
-	return nil
+	defer sc.cancel()
+
+	if sc.cmd.Process == nil {
+		return nil
+	}
+
+	return terminate(sc.cmd)
 }
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package rat
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+func setProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminate sends SIGTERM to the whole process group started by cmd, then
+// escalates to SIGKILL if it hasn't exited within terminateGracePeriod.
+// cmd.Wait() always runs, even when the initial Kill fails (e.g. ESRCH
+// because a short-lived command already exited on its own), so the child is
+// reaped instead of left as a zombie.
+func terminate(cmd *exec.Cmd) error {
+	pgid := cmd.Process.Pid
+
+	killErr := syscall.Kill(-pgid, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return killErr
+	case <-time.After(terminateGracePeriod):
+		return syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
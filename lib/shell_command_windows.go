@@ -0,0 +1,37 @@
+//go:build windows
+
+package rat
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func setProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminate asks the process group to shut down via CTRL_BREAK_EVENT, only
+// falling back to taskkill /T /F when the console control event can't be
+// delivered.
+func terminate(cmd *exec.Cmd) error {
+	if err := generateConsoleCtrlEvent(cmd.Process.Pid); err == nil {
+		return nil
+	}
+
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+	return kill.Run()
+}
+
+func generateConsoleCtrlEvent(pid int) error {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	proc := kernel32.NewProc("GenerateConsoleCtrlEvent")
+
+	r, _, err := proc.Call(uintptr(syscall.CTRL_BREAK_EVENT), uintptr(pid))
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
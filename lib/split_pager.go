@@ -0,0 +1,216 @@
+package rat
+
+import (
+	"sync"
+	"time"
+)
+
+// Orientation controls how NewSplitPager divides its Box between the parent
+// and preview panes.
+type Orientation int
+
+const (
+	Horizontal Orientation = iota
+	Vertical
+)
+
+const previewDebounce = 80 * time.Millisecond
+
+// splitPager composes a parent Pager with a preview Pager whose command is
+// re-interpolated from the parent's annotations under the cursor, modeled on
+// the bookmarks overlay pattern from bombadillo.
+type splitPager struct {
+	parent      Pager
+	parentCmd   *cmdPager
+	childCmd    string
+	orientation Orientation
+	ratio       float64
+	focusChild  bool
+
+	box       Box
+	parentBox Box
+	childBox  Box
+
+	mu             sync.Mutex
+	child          Pager
+	debounce       *time.Timer
+	eventListeners map[keyEvent]func()
+}
+
+// NewSplitPager wires parent up so that, whenever its cursor moves, the
+// annotations for the line under the cursor are interpolated into childCmd
+// and a fresh preview pager is built. reloads are debounced so rapid j/k
+// scrolling doesn't spawn a process per line.
+func NewSplitPager(parent Pager, childCmd string, orientation Orientation, ratio float64) Pager {
+	sp := &splitPager{
+		parent:      parent,
+		childCmd:    childCmd,
+		orientation: orientation,
+		ratio:       ratio,
+	}
+
+	sp.eventListeners = make(map[keyEvent]func())
+	sp.AddEventListener("tab", sp.toggleFocus)
+
+	if pc, ok := parent.(*cmdPager); ok {
+		sp.parentCmd = pc
+		pc.onCursorMove = sp.scheduleReload
+		sp.reloadChild(pc.cursorY)
+	}
+
+	return sp
+}
+
+func (sp *splitPager) toggleFocus() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	sp.focusChild = !sp.focusChild
+}
+
+// focused reads focusChild/child under sp.mu since child is written by the
+// debounced reload goroutine (reloadChild) and focusChild by toggleFocus,
+// while this is read from the UI goroutine handling key events.
+func (sp *splitPager) focused() Pager {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.focusChild && sp.child != nil {
+		return sp.child
+	}
+
+	return sp.parent
+}
+
+func (sp *splitPager) scheduleReload(cursorY int) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.debounce != nil {
+		sp.debounce.Stop()
+	}
+
+	sp.debounce = time.AfterFunc(previewDebounce, func() {
+		sp.reloadChild(cursorY)
+	})
+}
+
+func (sp *splitPager) reloadChild(cursorY int) {
+	if sp.parentCmd == nil {
+		return
+	}
+
+	sp.parentCmd.buffer.Lock()
+	annotations := sp.parentCmd.buffer.AnnotationsForLine(sp.parentCmd.logicalLine(cursorY))
+	sp.parentCmd.buffer.Unlock()
+
+	ctx := Context{}
+	for _, a := range annotations {
+		ctx[a.Class()] = a.Val()
+	}
+
+	resolvedCmd := InterpolateContext(sp.childCmd, ctx)
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.child != nil {
+		sp.child.Destroy()
+	}
+
+	sp.child = NewCmdPager("", resolvedCmd, ctx)
+
+	if sp.childBox != nil {
+		sp.child.SetBox(sp.childBox)
+	}
+}
+
+func (sp *splitPager) AddEventListener(keyStr string, handler func()) {
+	sp.eventListeners[KeyEventFromString(keyStr)] = handler
+}
+
+func (sp *splitPager) AddAnnotationEventListener(keyStr string, annotationTypes []string, handler func(Context)) {
+	sp.parent.AddAnnotationEventListener(keyStr, annotationTypes, handler)
+}
+
+func (sp *splitPager) Reload() {
+	sp.parent.Reload()
+}
+
+func (sp *splitPager) CursorUp()        { sp.focused().CursorUp() }
+func (sp *splitPager) CursorDown()      { sp.focused().CursorDown() }
+func (sp *splitPager) CursorFirstLine() { sp.focused().CursorFirstLine() }
+func (sp *splitPager) CursorLastLine()  { sp.focused().CursorLastLine() }
+func (sp *splitPager) ScrollUp()        { sp.focused().ScrollUp() }
+func (sp *splitPager) ScrollDown()      { sp.focused().ScrollDown() }
+func (sp *splitPager) PageUp()          { sp.focused().PageUp() }
+func (sp *splitPager) PageDown()        { sp.focused().PageDown() }
+func (sp *splitPager) Wrap() bool       { return sp.focused().Wrap() }
+
+func (sp *splitPager) SetBox(box Box) {
+	sp.box = box
+
+	var parentBox, childBox Box
+
+	if sp.orientation == Horizontal {
+		splitWidth := int(float64(box.Width()) * sp.ratio)
+		parentBox = NewBox(box.Left(), box.Top(), splitWidth, box.Height())
+		childBox = NewBox(box.Left()+splitWidth, box.Top(), box.Width()-splitWidth, box.Height())
+	} else {
+		splitHeight := int(float64(box.Height()) * sp.ratio)
+		parentBox = NewBox(box.Left(), box.Top(), box.Width(), splitHeight)
+		childBox = NewBox(box.Left(), box.Top()+splitHeight, box.Width(), box.Height()-splitHeight)
+	}
+
+	sp.parentBox = parentBox
+	sp.parent.SetBox(parentBox)
+
+	sp.mu.Lock()
+	sp.childBox = childBox
+	child := sp.child
+	sp.mu.Unlock()
+
+	if child != nil {
+		child.SetBox(childBox)
+	}
+}
+
+func (sp *splitPager) GetBox() Box {
+	return sp.box
+}
+
+func (sp *splitPager) Render() {
+	sp.parent.Render()
+
+	sp.mu.Lock()
+	child := sp.child
+	sp.mu.Unlock()
+
+	if child != nil {
+		child.Render()
+	}
+}
+
+func (sp *splitPager) Destroy() {
+	sp.mu.Lock()
+	if sp.debounce != nil {
+		sp.debounce.Stop()
+	}
+	child := sp.child
+	sp.mu.Unlock()
+
+	sp.parent.Destroy()
+
+	if child != nil {
+		child.Destroy()
+	}
+}
+
+func (sp *splitPager) HandleEvent(ke keyEvent) bool {
+	if handler, ok := sp.eventListeners[ke]; ok {
+		handler()
+		return true
+	}
+
+	return sp.focused().HandleEvent(ke)
+}
@@ -0,0 +1,182 @@
+package rat
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// Theme identifies the active light/dark terminal background, used to pick
+// readable colors in ThemedStyle.
+type Theme int
+
+const (
+	Dark Theme = iota
+	Light
+)
+
+type themeRole int
+
+const (
+	RoleCursor themeRole = iota
+	RoleHeader
+	RoleMatch
+	RoleAnnotation
+)
+
+type themePalette map[themeRole]termbox.Attribute
+
+var gTheme = Dark
+
+var themeFlag = flag.String("theme", "auto", "color theme: dark, light, or auto (detect from terminal background)")
+
+var themeInitOnce sync.Once
+
+// ensureThemeInitialized parses --theme on first use and resolves gTheme from
+// it, so every pager picks up the flag without requiring a main() in this
+// package to remember to call InitTheme itself.
+func ensureThemeInitialized() {
+	themeInitOnce.Do(func() {
+		if !flag.Parsed() {
+			flag.Parse()
+		}
+
+		InitTheme(*themeFlag)
+	})
+}
+
+var themePalettes = map[Theme]themePalette{
+	Dark: {
+		RoleCursor:     termbox.ColorRed,
+		RoleHeader:     termbox.ColorWhite,
+		RoleMatch:      termbox.ColorYellow,
+		RoleAnnotation: termbox.ColorCyan,
+	},
+	Light: {
+		RoleCursor:     termbox.ColorRed,
+		RoleHeader:     termbox.ColorBlack,
+		RoleMatch:      termbox.ColorMagenta,
+		RoleAnnotation: termbox.ColorBlue,
+	},
+}
+
+// RegisterPalette lets a mode override the colors used for a role in a given
+// theme, so annotation classes can stay readable against either background.
+func RegisterPalette(theme Theme, role themeRole, attr termbox.Attribute) {
+	themePalettes[theme][role] = attr
+}
+
+// ThemedStyle resolves a role to a termbox style for the currently active
+// theme. It is used for the header counter, the cursor marker and search
+// match highlighting; the chroma annotator takes its colors from the chroma
+// style instead.
+func ThemedStyle(role themeRole) termbox.Attribute {
+	return gTermStyles.Get(termbox.AttrBold, themePalettes[gTheme][role])
+}
+
+// InitTheme resolves the active Theme from the --theme flag value ("dark",
+// "light" or "auto"), falling back to terminal detection when auto.
+func InitTheme(flagValue string) {
+	switch strings.ToLower(flagValue) {
+	case "dark":
+		gTheme = Dark
+	case "light":
+		gTheme = Light
+	default:
+		gTheme = detectTheme()
+	}
+}
+
+func detectTheme() Theme {
+	if bg, ok := queryOSC11Background(); ok {
+		return themeFromBackground(bg)
+	}
+
+	if bg, ok := themeFromColorFgBg(os.Getenv("COLORFGBG")); ok {
+		return bg
+	}
+
+	return Dark
+}
+
+var osc11Response = regexp.MustCompile(`rgb:([0-9a-fA-F]+)/([0-9a-fA-F]+)/([0-9a-fA-F]+)`)
+
+// queryOSC11Background writes an OSC 11 query to stdout and reads the
+// terminal's reply from stdin, bounded by a short timeout since not every
+// terminal answers. termbox is reset afterwards in case the reply was
+// echoed into its input buffer.
+func queryOSC11Background() (Theme, bool) {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return Dark, false
+	}
+
+	os.Stdout.WriteString("\x1b]11;?\x1b\\")
+	defer termbox.Sync()
+
+	type result struct {
+		line string
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		line, err := reader.ReadString('\\')
+		done <- result{line, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return Dark, false
+		}
+
+		m := osc11Response.FindStringSubmatch(res.line)
+		if m == nil {
+			return Dark, false
+		}
+
+		r, _ := strconv.ParseInt(m[1][:2], 16, 64)
+		g, _ := strconv.ParseInt(m[2][:2], 16, 64)
+		b, _ := strconv.ParseInt(m[3][:2], 16, 64)
+
+		return themeFromLuminance(r, g, b), true
+	case <-time.After(100 * time.Millisecond):
+		return Dark, false
+	}
+}
+
+func themeFromLuminance(r, g, b int64) Theme {
+	luminance := (299*r + 587*g + 114*b) / 1000
+	if luminance < 128 {
+		return Dark
+	}
+
+	return Light
+}
+
+func themeFromColorFgBg(value string) (Theme, bool) {
+	if value == "" {
+		return Dark, false
+	}
+
+	parts := strings.Split(value, ";")
+	bg, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return Dark, false
+	}
+
+	if bg >= 0 && bg <= 6 || bg == 8 {
+		return Dark, true
+	}
+
+	return Light, true
+}
@@ -0,0 +1,104 @@
+package rat
+
+// rebuildVisualRows recomputes the logical-to-visual row mapping for the
+// current content box width and buffer line count.
+func (p *cmdPager) rebuildVisualRows() {
+	if p.buffer == nil || p.contentBox == nil {
+		p.visualRows = nil
+		p.visualRowsLines = 0
+		return
+	}
+
+	if !p.wrap {
+		p.visualRows = make([]VisualRow, p.buffer.NumLines())
+		for i := range p.visualRows {
+			p.visualRows[i] = VisualRow{Line: i}
+		}
+
+		p.visualRowsLines = p.buffer.NumLines()
+		return
+	}
+
+	width := p.contentBox.Width() - 3
+	if width < 1 {
+		width = 1
+	}
+
+	p.visualRows = p.buffer.WrappedLines(0, p.buffer.NumLines(), width)
+	p.visualRowsLines = p.buffer.NumLines()
+}
+
+// ensureVisualRowsFresh rebuilds the visual row mapping if the buffer has
+// grown (or shrunk, on Reload) since it was last built, so streaming output
+// keeps scrolling into view instead of being capped at the line count seen
+// right after the command started.
+func (p *cmdPager) ensureVisualRowsFresh() {
+	if p.buffer == nil {
+		return
+	}
+
+	if p.visualRows == nil || p.buffer.NumLines() != p.visualRowsLines {
+		p.rebuildVisualRows()
+	}
+}
+
+// numRows returns the number of rows MoveCursorToY/ScrollToY should clamp
+// against: visual rows when wrapping, logical lines otherwise.
+func (p *cmdPager) numRows() int {
+	p.ensureVisualRowsFresh()
+
+	if p.wrap {
+		return len(p.visualRows)
+	}
+
+	return p.buffer.NumLines()
+}
+
+// logicalLine translates a cursor/scroll row back to the buffer line it
+// belongs to, so annotation lookups stay keyed by logical line regardless of
+// wrap state.
+func (p *cmdPager) logicalLine(row int) int {
+	p.ensureVisualRowsFresh()
+
+	if !p.wrap || row < 0 || row >= len(p.visualRows) {
+		return row
+	}
+
+	return p.visualRows[row].Line
+}
+
+// visualRowForLine is the inverse of logicalLine: the first visual row at
+// which logical line starts, used when something (search, the split-pane
+// preview) only knows the logical line number.
+func (p *cmdPager) visualRowForLine(line int) int {
+	p.ensureVisualRowsFresh()
+
+	if !p.wrap {
+		return line
+	}
+
+	for i, row := range p.visualRows {
+		if row.Line == line {
+			return i
+		}
+	}
+
+	return line
+}
+
+// ToggleWrap flips between hard-wrapping long lines and the previous
+// truncate-at-box-width behavior, bound to the "w" key by default. The
+// cursor is re-anchored to the same logical line across the toggle, since
+// wrap and no-wrap produce differently sized row mappings for it.
+func (p *cmdPager) ToggleWrap() {
+	savedLine := p.logicalLine(p.cursorY)
+
+	p.wrap = !p.wrap
+	p.rebuildVisualRows()
+
+	p.MoveCursorToY(p.visualRowForLine(savedLine))
+}
+
+func (p *cmdPager) Wrap() bool {
+	return p.wrap
+}